@@ -0,0 +1,92 @@
+package goxz
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// archiveTarXz writes files as a .tar.xz archive.
+func (b *builder) archiveTarXz(files []string) (string, error) {
+	dest := filepath.Join(b.dest, b.archiveBaseName()+".tar.xz")
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	xw, err := xz.NewWriter(f)
+	if err != nil {
+		return "", err
+	}
+	tw := tar.NewWriter(xw)
+	for _, file := range files {
+		if err := addFileToTar(tw, file); err != nil {
+			tw.Close()
+			xw.Close()
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	return dest, xw.Close()
+}
+
+// archiveTarZst writes files as a .tar.zst archive.
+func (b *builder) archiveTarZst(files []string) (string, error) {
+	dest := filepath.Join(b.dest, b.archiveBaseName()+".tar.zst")
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		return "", err
+	}
+	tw := tar.NewWriter(zw)
+	for _, file := range files {
+		if err := addFileToTar(tw, file); err != nil {
+			tw.Close()
+			zw.Close()
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	return dest, zw.Close()
+}
+
+// archiveTarBz2 writes files as a .tar.bz2 archive.
+func (b *builder) archiveTarBz2(files []string) (string, error) {
+	dest := filepath.Join(b.dest, b.archiveBaseName()+".tar.bz2")
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	bw, err := bzip2.NewWriter(f, nil)
+	if err != nil {
+		return "", err
+	}
+	tw := tar.NewWriter(bw)
+	for _, file := range files {
+		if err := addFileToTar(tw, file); err != nil {
+			tw.Close()
+			bw.Close()
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	return dest, bw.Close()
+}
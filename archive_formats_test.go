@@ -0,0 +1,132 @@
+package goxz
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// readTarArchive decompresses src with decompress and returns the contents
+// of every file in the resulting tar stream, keyed by base name.
+func readTarArchive(t *testing.T, src string, decompress func(io.Reader) (io.Reader, error)) map[string]string {
+	t.Helper()
+	f, err := os.Open(src)
+	if err != nil {
+		t.Fatalf("failed to open %s: %s", src, err)
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		t.Fatalf("failed to decompress %s: %s", src, err)
+	}
+
+	got := make(map[string]string)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar entry: %s", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read tar entry %s: %s", hdr.Name, err)
+		}
+		got[hdr.Name] = string(data)
+	}
+	return got
+}
+
+func testArchiveRoundTrip(t *testing.T, ext string, archive func(b *builder, files []string) (string, error), decompress func(io.Reader) (io.Reader, error)) {
+	t.Helper()
+	dir := t.TempDir()
+	binPath := filepath.Join(dir, "mybinary")
+	if err := os.WriteFile(binPath, []byte("#!/bin/sh\necho hi\n"), 0755); err != nil {
+		t.Fatalf("failed to write fixture binary: %s", err)
+	}
+	resPath := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(resPath, []byte("readme contents"), 0644); err != nil {
+		t.Fatalf("failed to write fixture resource: %s", err)
+	}
+
+	b := &builder{
+		name:    "myapp",
+		version: "v1.0",
+		dest:    dir,
+		platform: &platform{
+			os:   "linux",
+			arch: "amd64",
+		},
+	}
+
+	dest, err := archive(b, []string{binPath, resPath})
+	if err != nil {
+		t.Fatalf("archive %s unexpected error: %s", ext, err)
+	}
+	wantDest := filepath.Join(dir, "myapp_v1.0_linux_amd64"+ext)
+	if dest != wantDest {
+		t.Errorf("archive %s dest = %q, want %q", ext, dest, wantDest)
+	}
+
+	got := readTarArchive(t, dest, decompress)
+	want := map[string]string{
+		"mybinary":  "#!/bin/sh\necho hi\n",
+		"README.md": "readme contents",
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("archive %s entry %q = %q, want %q", ext, name, got[name], content)
+		}
+	}
+}
+
+func TestArchiveTarXz(t *testing.T) {
+	testArchiveRoundTrip(t, ".tar.xz",
+		func(b *builder, files []string) (string, error) { return b.archiveTarXz(files) },
+		func(r io.Reader) (io.Reader, error) { return xz.NewReader(r) },
+	)
+}
+
+func TestArchiveTarZst(t *testing.T) {
+	testArchiveRoundTrip(t, ".tar.zst",
+		func(b *builder, files []string) (string, error) { return b.archiveTarZst(files) },
+		func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	)
+}
+
+func TestArchiveTarBz2(t *testing.T) {
+	testArchiveRoundTrip(t, ".tar.bz2",
+		func(b *builder, files []string) (string, error) { return b.archiveTarBz2(files) },
+		func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r, nil) },
+	)
+}
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		os        string
+		zipAlways bool
+		want      string
+	}{
+		{name: "explicit format wins", format: "tar.xz", os: "windows", want: "tar.xz"},
+		{name: "windows defaults to zip", format: "", os: "windows", want: "zip"},
+		{name: "zipAlways forces zip", format: "", os: "linux", zipAlways: true, want: "zip"},
+		{name: "otherwise defaults to tar.gz", format: "", os: "linux", want: "tar.gz"},
+	}
+	for _, tt := range tests {
+		b := &builder{format: tt.format, zipAlways: tt.zipAlways, platform: &platform{os: tt.os}}
+		if got := b.resolveFormat(); got != tt.want {
+			t.Errorf("%s: resolveFormat() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package goxz
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// stdoutMu and stderrMu serialize writes to the shared os.Stdout/os.Stderr
+// streams across concurrently running builders, so that one builder's
+// buffered output is flushed as a whole instead of interleaving with
+// another's.
+var stdoutMu, stderrMu sync.Mutex
+
+// builder builds and archives a binary for a single platform.
+type builder struct {
+	platform     *platform
+	name         string
+	version      string
+	output       string
+	buildLdFlags string
+	buildTags    string
+	pkgs         []string
+	zipAlways    bool
+	format       string
+	cc, cxx      string
+	cgoEnabled   bool
+	buildmode    string
+	extraEnv     map[string]string
+	workDirBase  string
+	resources    []string
+	dest         string
+}
+
+// cgoEnv returns the CGO_ENABLED/CC/CXX environment variables to apply for
+// this platform's build, based on whether a cross-compiler was configured
+// for it via -cc/-cxx or cgo was forced on via -cgo.
+func (b *builder) cgoEnv() []string {
+	if !b.cgoEnabled {
+		return []string{"CGO_ENABLED=0"}
+	}
+	env := []string{"CGO_ENABLED=1"}
+	if b.cc != "" {
+		env = append(env, "CC="+b.cc)
+	}
+	if b.cxx != "" {
+		env = append(env, "CXX="+b.cxx)
+	}
+	return env
+}
+
+// archiveBaseName returns the archive name without extension, e.g. "myapp_v1.0_linux_amd64".
+func (b *builder) archiveBaseName() string {
+	name := b.name
+	if b.version != "" {
+		name += "_" + b.version
+	}
+	name += "_" + b.platform.os + "_" + b.platform.archSuffix()
+	return name
+}
+
+// writePrefixed flushes buf to w, one write per line prefixed with
+// pf, holding mu for the duration so that concurrently running builders
+// don't interleave their output.
+func writePrefixed(mu *sync.Mutex, w io.Writer, pf fmt.Stringer, buf *bytes.Buffer) {
+	if buf.Len() == 0 {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		fmt.Fprintf(w, "[%s] %s\n", pf, scanner.Text())
+	}
+}
+
+// build compiles the package for b.platform and archives the resulting
+// binary (plus resources) into b.dest. It returns the path to the produced
+// archive.
+func (b *builder) build() (string, error) {
+	workDir := filepath.Join(b.workDirBase, b.archiveBaseName())
+	if err := os.MkdirAll(workDir, 0777); err != nil {
+		return "", err
+	}
+
+	binName := b.name
+	if ext := buildModeOutputExt(b.buildmode, b.platform.os); ext != "" {
+		binName += ext
+	} else if b.platform.os == "windows" {
+		binName += ".exe"
+	}
+	binPath := filepath.Join(workDir, binName)
+
+	args := []string{"build", "-o", binPath}
+	if b.buildmode != "" && b.buildmode != "default" {
+		args = append(args, "-buildmode", b.buildmode)
+	}
+	if b.buildLdFlags != "" {
+		args = append(args, "-ldflags", b.buildLdFlags)
+	}
+	if b.buildTags != "" {
+		args = append(args, "-tags", b.buildTags)
+	}
+	args = append(args, b.pkgs...)
+
+	cmd := exec.Command("go", args...)
+	cmd.Env = append(os.Environ(),
+		"GOOS="+b.platform.os,
+		"GOARCH="+b.platform.arch,
+	)
+	cmd.Env = append(cmd.Env, b.cgoEnv()...)
+	for k, v := range b.extraEnv {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	writePrefixed(&stdoutMu, os.Stdout, b.platform, &stdout)
+	writePrefixed(&stderrMu, os.Stderr, b.platform, &stderr)
+	if runErr != nil {
+		return "", fmt.Errorf("failed to build for %s: %w", b.platform, runErr)
+	}
+
+	files := []string{binPath}
+	for _, res := range b.resources {
+		files = append(files, res)
+	}
+
+	switch b.resolveFormat() {
+	case "zip":
+		return b.archiveZip(files)
+	case "tar.xz":
+		return b.archiveTarXz(files)
+	case "tar.zst":
+		return b.archiveTarZst(files)
+	case "tar.bz2":
+		return b.archiveTarBz2(files)
+	default:
+		return b.archiveTarGz(files)
+	}
+}
+
+// validFormats is the set of archive formats accepted by -format.
+var validFormats = map[string]bool{
+	"":        true,
+	"zip":     true,
+	"tar.gz":  true,
+	"tar.xz":  true,
+	"tar.zst": true,
+	"tar.bz2": true,
+}
+
+// resolveFormat returns the archive format to use for this platform: the
+// explicit -format value if set, otherwise the historical default of zip
+// for windows (or for every platform when -zip is set) and tar.gz for
+// everything else.
+func (b *builder) resolveFormat() string {
+	if b.format != "" {
+		return b.format
+	}
+	if b.platform.os == "windows" || b.zipAlways {
+		return "zip"
+	}
+	return "tar.gz"
+}
+
+func (b *builder) archiveZip(files []string) (string, error) {
+	dest := filepath.Join(b.dest, b.archiveBaseName()+".zip")
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		if err := addFileToZip(zw, file); err != nil {
+			zw.Close()
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+func (b *builder) archiveTarGz(files []string) (string, error) {
+	dest := filepath.Join(b.dest, b.archiveBaseName()+".tar.gz")
+	f, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		if err := addFileToTar(tw, file); err != nil {
+			tw.Close()
+			gw.Close()
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	return dest, gw.Close()
+}
+
+func addFileToZip(zw *zip.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.Base(path)
+	hdr.Method = zip.Deflate
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(w, src)
+	return err
+}
+
+func addFileToTar(tw *tar.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.Base(path)
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(tw, src)
+	return err
+}
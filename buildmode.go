@@ -0,0 +1,88 @@
+package goxz
+
+import "log"
+
+// buildModeSupport lists, for build modes other than "default", the
+// GOOS/GOARCH combinations the Go toolchain is able to produce them for.
+// Mirrors the support matrix documented by `go help buildmode`.
+var buildModeSupport = map[string]map[string]bool{
+	"pie": {
+		"linux/amd64": true, "linux/arm": true, "linux/arm64": true, "linux/386": true, "linux/ppc64le": true, "linux/s390x": true,
+		"windows/amd64": true, "windows/386": true, "windows/arm64": true,
+		"darwin/amd64": true, "darwin/arm64": true,
+		"android/amd64": true, "android/arm": true, "android/arm64": true, "android/386": true,
+	},
+	"plugin": {
+		"linux/amd64": true, "linux/arm": true, "linux/arm64": true, "linux/386": true,
+		"android/amd64": true, "android/arm": true, "android/arm64": true, "android/386": true,
+	},
+	"c-archive": {
+		"linux/amd64": true, "linux/arm": true, "linux/arm64": true, "linux/386": true,
+		"darwin/amd64": true, "darwin/arm64": true,
+		"windows/amd64": true, "windows/386": true,
+	},
+	"c-shared": {
+		"linux/amd64": true, "linux/arm": true, "linux/arm64": true, "linux/386": true,
+		"darwin/amd64": true, "darwin/arm64": true,
+		"windows/amd64": true, "windows/386": true,
+		"android/amd64": true, "android/arm": true, "android/arm64": true, "android/386": true,
+	},
+}
+
+// buildModeSupported reports whether mode can be built for the given
+// platform. "default" (and "") are always supported.
+func buildModeSupported(mode string, pf *platform) bool {
+	if mode == "" || mode == "default" {
+		return true
+	}
+	supported, ok := buildModeSupport[mode]
+	if !ok {
+		return false
+	}
+	return supported[pf.os+"/"+pf.arch]
+}
+
+// filterPlatformsForBuildMode drops platforms that cannot produce the
+// requested build mode, logging a warning for each one dropped.
+func filterPlatformsForBuildMode(platforms []*platform, mode string) []*platform {
+	if mode == "" || mode == "default" {
+		return platforms
+	}
+	kept := platforms[:0]
+	for _, pf := range platforms {
+		if buildModeSupported(mode, pf) {
+			kept = append(kept, pf)
+			continue
+		}
+		log.Printf("warning: skipping %s: buildmode %q is not supported on this platform", pf, mode)
+	}
+	return kept
+}
+
+// buildModeOutputExt returns the file extension a binary built with mode
+// carries for the given GOOS, overriding the platform's usual executable
+// extension.
+func buildModeOutputExt(mode, goos string) string {
+	switch mode {
+	case "plugin":
+		return ".so"
+	case "c-shared":
+		switch goos {
+		case "windows":
+			return ".dll"
+		case "darwin":
+			return ".dylib"
+		default:
+			return ".so"
+		}
+	case "c-archive":
+		switch goos {
+		case "windows":
+			return ".lib"
+		default:
+			return ".a"
+		}
+	default:
+		return ""
+	}
+}
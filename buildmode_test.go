@@ -0,0 +1,41 @@
+package goxz
+
+import "testing"
+
+func TestBuildModeSupported(t *testing.T) {
+	tests := []struct {
+		mode string
+		pf   *platform
+		want bool
+	}{
+		{mode: "", pf: &platform{os: "plan9", arch: "amd64"}, want: true},
+		{mode: "default", pf: &platform{os: "plan9", arch: "amd64"}, want: true},
+		{mode: "plugin", pf: &platform{os: "linux", arch: "amd64"}, want: true},
+		{mode: "plugin", pf: &platform{os: "windows", arch: "amd64"}, want: false},
+		{mode: "c-archive", pf: &platform{os: "darwin", arch: "arm64"}, want: true},
+		{mode: "bogus-mode", pf: &platform{os: "linux", arch: "amd64"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := buildModeSupported(tt.mode, tt.pf); got != tt.want {
+			t.Errorf("buildModeSupported(%q, %s) = %v, want %v", tt.mode, tt.pf, got, tt.want)
+		}
+	}
+}
+
+func TestFilterPlatformsForBuildMode(t *testing.T) {
+	platforms := []*platform{
+		{os: "linux", arch: "amd64"},
+		{os: "windows", arch: "amd64"},
+		{os: "darwin", arch: "amd64"},
+	}
+
+	got := filterPlatformsForBuildMode(platforms, "plugin")
+	if len(got) != 1 || got[0].os != "linux" {
+		t.Errorf("filterPlatformsForBuildMode(plugin) = %v, want only linux/amd64", got)
+	}
+
+	got = filterPlatformsForBuildMode(platforms, "")
+	if len(got) != len(platforms) {
+		t.Errorf("filterPlatformsForBuildMode(\"\") dropped platforms, want all %d kept, got %d", len(platforms), len(got))
+	}
+}
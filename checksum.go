@@ -0,0 +1,91 @@
+package goxz
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var archiveExtReg = regexp.MustCompile(`\.(?:zip|tar\.gz|tar\.xz|tar\.zst|tar\.bz2)$`)
+
+// newChecksumHash returns a fresh hash.Hash for the given algorithm name
+// ("sha1", "sha256" or "sha512").
+func newChecksumHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %q", algo)
+	}
+}
+
+// writeChecksums walks dir for produced archives and writes one manifest
+// file per requested algorithm (e.g. SHA256SUMS) in `shasum -c` compatible
+// format: "<hex>  <filename>".
+func writeChecksums(dir, checksum string) error {
+	if checksum == "" {
+		return nil
+	}
+	algos := separateReg.Split(checksum, -1)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var archives []string
+	for _, f := range files {
+		if f.Mode().IsRegular() && archiveExtReg.MatchString(f.Name()) {
+			archives = append(archives, f.Name())
+		}
+	}
+
+	for _, algo := range algos {
+		algo = strings.TrimSpace(algo)
+		if algo == "" {
+			continue
+		}
+		if err := writeChecksumFile(dir, algo, archives); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeChecksumFile(dir, algo string, archives []string) error {
+	manifest := filepath.Join(dir, strings.ToUpper(algo)+"SUMS")
+	out, err := os.Create(manifest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for _, name := range archives {
+		h, err := newChecksumHash(algo)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%x  %s\n", h.Sum(nil), name)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package goxz
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewChecksumHash(t *testing.T) {
+	for _, algo := range []string{"sha1", "sha256", "sha512"} {
+		if _, err := newChecksumHash(algo); err != nil {
+			t.Errorf("newChecksumHash(%q) unexpected error: %s", algo, err)
+		}
+	}
+
+	if _, err := newChecksumHash("md5"); err == nil {
+		t.Error("newChecksumHash(\"md5\") error = nil, want error for unsupported algorithm")
+	}
+}
+
+func TestWriteChecksumFile(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("archive contents")
+	if err := os.WriteFile(filepath.Join(dir, "myapp_linux_amd64.tar.gz"), content, 0644); err != nil {
+		t.Fatalf("failed to write fixture archive: %s", err)
+	}
+
+	if err := writeChecksumFile(dir, "sha256", []string{"myapp_linux_amd64.tar.gz"}); err != nil {
+		t.Fatalf("writeChecksumFile() unexpected error: %s", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatalf("failed to read SHA256SUMS: %s", err)
+	}
+
+	sum := sha256.Sum256(content)
+	want := fmt.Sprintf("%x  myapp_linux_amd64.tar.gz\n", sum)
+	if string(got) != want {
+		t.Errorf("SHA256SUMS content = %q, want %q", got, want)
+	}
+}
+
+// TestWriteChecksumsOnlyCoversRecognizedArchives guards against the manifest
+// picking up stray files (e.g. a partially written workdir artifact) that
+// don't look like one of the archive formats goxz itself produces.
+func TestWriteChecksumsOnlyCoversRecognizedArchives(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"myapp_linux_amd64.tar.gz", "myapp_darwin_amd64.zip", "SHA256SUMS", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("failed to write fixture %s: %s", name, err)
+		}
+	}
+
+	if err := writeChecksums(dir, "sha256"); err != nil {
+		t.Fatalf("writeChecksums() unexpected error: %s", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatalf("failed to read SHA256SUMS: %s", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(got), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("SHA256SUMS has %d lines, want 2 (one per recognized archive): %q", len(lines), got)
+	}
+	for _, name := range []string{"myapp_linux_amd64.tar.gz", "myapp_darwin_amd64.zip"} {
+		if !strings.Contains(string(got), name) {
+			t.Errorf("SHA256SUMS missing entry for %s", name)
+		}
+	}
+}
+
+func TestWriteChecksumsMultipleAlgorithms(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "myapp_linux_amd64.tar.gz"), []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to write fixture archive: %s", err)
+	}
+
+	if err := writeChecksums(dir, "sha1,sha256"); err != nil {
+		t.Fatalf("writeChecksums() unexpected error: %s", err)
+	}
+
+	for _, manifest := range []string{"SHA1SUMS", "SHA256SUMS"} {
+		if _, err := os.Stat(filepath.Join(dir, manifest)); err != nil {
+			t.Errorf("expected manifest %s to exist: %s", manifest, err)
+		}
+	}
+}
+
+func TestWriteChecksumsEmptyIsNoop(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeChecksums(dir, ""); err != nil {
+		t.Fatalf("writeChecksums() unexpected error: %s", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("writeChecksums(\"\") wrote %d files, want 0", len(entries))
+	}
+}
@@ -0,0 +1,109 @@
+package goxz
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// matrixConfig describes a full release matrix loaded from a -config file:
+// shared build settings plus one target per platform to produce.
+type matrixConfig struct {
+	Name      string         `yaml:"name" toml:"name"`
+	Version   string         `yaml:"version" toml:"version"`
+	LdFlags   string         `yaml:"ldflags" toml:"ldflags"`
+	Tags      string         `yaml:"tags" toml:"tags"`
+	Resources []string       `yaml:"resources" toml:"resources"`
+	Targets   []matrixTarget `yaml:"targets" toml:"targets"`
+}
+
+// matrixTarget describes a single platform entry within a matrixConfig.
+type matrixTarget struct {
+	OS        string            `yaml:"os" toml:"os"`
+	Arch      string            `yaml:"arch" toml:"arch"`
+	GOARM     string            `yaml:"goarm" toml:"goarm"`
+	GOAMD64   string            `yaml:"goamd64" toml:"goamd64"`
+	Cgo       bool              `yaml:"cgo" toml:"cgo"`
+	Env       map[string]string `yaml:"env" toml:"env"`
+	BuildMode string            `yaml:"buildmode" toml:"buildmode"`
+	Files     []string          `yaml:"files" toml:"files"`
+}
+
+// loadConfigTargets reads a YAML or TOML matrix config (selected by file
+// extension) and expands it into one *goxz per target, all sharing dest as
+// their destination directory.
+func loadConfigTargets(path, dest string) ([]*goxz, error) {
+	cfg, err := parseMatrixConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]*goxz, len(cfg.Targets))
+	for i, t := range cfg.Targets {
+		env := make(map[string]string, len(t.Env))
+		for k, v := range t.Env {
+			env[k] = v
+		}
+
+		targets[i] = &goxz{
+			name:           cfg.Name,
+			version:        cfg.Version,
+			os:             t.OS,
+			arch:           targetArch(t),
+			buildLdFlags:   cfg.LdFlags,
+			buildTags:      cfg.Tags,
+			buildmode:      t.BuildMode,
+			cgo:            cgoOverride{set: t.Cgo, value: t.Cgo},
+			extraEnv:       env,
+			extraResources: append(append([]string{}, cfg.Resources...), t.Files...),
+			pkgs:           []string{"."},
+			dest:           dest,
+			skipDestSetup:  true,
+		}
+	}
+	return targets, nil
+}
+
+// targetArch returns t.Arch, pinned to t.GOARM/t.GOAMD64 via the same
+// "arch@variant" suffix resolvePlatforms understands for -arch, so that
+// targets differing only by microarchitecture level (e.g. linux/amd64 v1
+// vs v2) resolve to distinct platform.variant values and distinct archive
+// filenames instead of colliding.
+func targetArch(t matrixTarget) string {
+	var variant string
+	switch t.Arch {
+	case "arm":
+		variant = t.GOARM
+	case "amd64":
+		variant = t.GOAMD64
+	}
+	if variant == "" {
+		return t.Arch
+	}
+	return t.Arch + "@" + variant
+}
+
+func parseMatrixConfig(path string) (*matrixConfig, error) {
+	cfg := &matrixConfig{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case ".toml":
+		if _, err := toml.DecodeFile(path, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config extension %q (want .yaml, .yml or .toml)", ext)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,112 @@
+package goxz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMatrixYAML = `
+name: myapp
+version: v1.0
+ldflags: -s -w
+targets:
+  - os: linux
+    arch: amd64
+    goamd64: v1
+  - os: linux
+    arch: amd64
+    goamd64: v2
+  - os: linux
+    arch: arm
+    goarm: "6"
+  - os: linux
+    arch: arm
+    goarm: "7"
+`
+
+const testMatrixTOML = `
+name = "myapp"
+version = "v1.0"
+
+[[targets]]
+os = "darwin"
+arch = "amd64"
+
+[[targets]]
+os = "darwin"
+arch = "arm64"
+`
+
+func writeTempConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %s", path, err)
+	}
+	return path
+}
+
+func TestParseMatrixConfigYAML(t *testing.T) {
+	path := writeTempConfig(t, "goxz.yaml", testMatrixYAML)
+	cfg, err := parseMatrixConfig(path)
+	if err != nil {
+		t.Fatalf("parseMatrixConfig() unexpected error: %s", err)
+	}
+	if cfg.Name != "myapp" || cfg.Version != "v1.0" {
+		t.Errorf("parseMatrixConfig() name/version = %q/%q, want myapp/v1.0", cfg.Name, cfg.Version)
+	}
+	if len(cfg.Targets) != 4 {
+		t.Fatalf("parseMatrixConfig() targets = %d, want 4", len(cfg.Targets))
+	}
+}
+
+func TestParseMatrixConfigTOML(t *testing.T) {
+	path := writeTempConfig(t, "goxz.toml", testMatrixTOML)
+	cfg, err := parseMatrixConfig(path)
+	if err != nil {
+		t.Fatalf("parseMatrixConfig() unexpected error: %s", err)
+	}
+	if len(cfg.Targets) != 2 {
+		t.Fatalf("parseMatrixConfig() targets = %d, want 2", len(cfg.Targets))
+	}
+}
+
+func TestParseMatrixConfigUnsupportedExtension(t *testing.T) {
+	path := writeTempConfig(t, "goxz.json", "{}")
+	if _, err := parseMatrixConfig(path); err == nil {
+		t.Fatal("parseMatrixConfig() error = nil, want error for unsupported extension")
+	}
+}
+
+// TestLoadConfigTargetsDistinctArchiveNames guards against the filename
+// collision where two targets sharing an os/arch but differing only by
+// GOAMD64/GOARM level used to produce identical archive names and clobber
+// each other in the shared dest directory.
+func TestLoadConfigTargetsDistinctArchiveNames(t *testing.T) {
+	path := writeTempConfig(t, "goxz.yaml", testMatrixYAML)
+	targets, err := loadConfigTargets(path, "goxz")
+	if err != nil {
+		t.Fatalf("loadConfigTargets() unexpected error: %s", err)
+	}
+	if len(targets) != 4 {
+		t.Fatalf("loadConfigTargets() targets = %d, want 4", len(targets))
+	}
+
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		platforms, err := resolvePlatforms(target.os, target.arch, "")
+		if err != nil {
+			t.Fatalf("resolvePlatforms(%q, %q) unexpected error: %s", target.os, target.arch, err)
+		}
+		if len(platforms) != 1 {
+			t.Fatalf("resolvePlatforms(%q, %q) = %d platforms, want 1", target.os, target.arch, len(platforms))
+		}
+		bdr := &builder{name: target.name, version: target.version, platform: platforms[0]}
+		name := bdr.archiveBaseName()
+		if seen[name] {
+			t.Errorf("archive name %q was produced by more than one target", name)
+		}
+		seen[name] = true
+	}
+}
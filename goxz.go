@@ -2,6 +2,7 @@ package goxz
 
 import (
 	"flag"
+	"fmt"
 	"go/build"
 	"io"
 	"io/ioutil"
@@ -9,9 +10,50 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+
+	"golang.org/x/sync/errgroup"
 )
 
+// cgoOverride is the tri-state value of -cgo: unset defers to whether a
+// -cc/-cxx mapping exists for the platform, while an explicit true/false
+// forces CGO_ENABLED on or off for every platform.
+type cgoOverride struct {
+	set   bool
+	value bool
+}
+
+func (c *cgoOverride) String() string {
+	if !c.set {
+		return ""
+	}
+	return strconv.FormatBool(c.value)
+}
+
+func (c *cgoOverride) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	c.set = true
+	c.value = v
+	return nil
+}
+
+func (c *cgoOverride) IsBoolFlag() bool { return true }
+
+// resolveCgoEnabled applies override on top of whether a -cc/-cxx mapping
+// exists for a platform: an explicit override always wins, otherwise cgo
+// is enabled exactly when a compiler mapping was configured.
+func resolveCgoEnabled(override cgoOverride, hasCC, hasCXX bool) bool {
+	if override.set {
+		return override.value
+	}
+	return hasCC || hasCXX
+}
+
 type cli struct {
 	outStream, errStream io.Writer
 }
@@ -40,6 +82,14 @@ type goxz struct {
 	dest, output, buildLdFlags, buildTags string
 	zipAlways                             bool
 	work                                  bool
+	parallelism                           int
+	checksum                              string
+	format                                string
+	cc, cxx                               string
+	cgo                                   cgoOverride
+	buildmode                             string
+	config                                string
+	archVariants                          string
 	pkgs                                  []string
 
 	absPkgs   []string
@@ -47,6 +97,14 @@ type goxz struct {
 	projDir   string
 	workDir   string
 	resources []string
+	ccMap     map[string]string
+	cxxMap    map[string]string
+
+	// extraEnv and extraResources are populated when this configuration
+	// was expanded from a -config matrix file.
+	extraEnv       map[string]string
+	extraResources []string
+	skipDestSetup  bool
 }
 
 func (cl *cli) run(args []string) error {
@@ -69,7 +127,34 @@ func (cl *cli) run(args []string) error {
 		}
 		defer os.Chdir(prev)
 	}
-	err = gx.init()
+
+	if gx.config != "" {
+		if err := setupDest(gx.getDest()); err != nil {
+			return err
+		}
+		targets, err := loadConfigTargets(gx.config, gx.getDest())
+		if err != nil {
+			return err
+		}
+		var failed bool
+		for _, target := range targets {
+			if err := cl.runOne(target); err != nil {
+				log.Printf("target %s/%s failed: %s", target.os, target.arch, err)
+				failed = true
+			}
+		}
+		if failed {
+			return fmt.Errorf("one or more targets failed")
+		}
+		return nil
+	}
+
+	return cl.runOne(gx)
+}
+
+// runOne builds and archives every platform of a single goxz configuration.
+func (cl *cli) runOne(gx *goxz) error {
+	err := gx.init()
 	if err != nil {
 		return err
 	}
@@ -86,14 +171,56 @@ func (cl *cli) run(args []string) error {
 		log.Printf("working dir: %s\n", gx.workDir)
 	}
 
-	for _, bdr := range gx.builders() {
-		// XXX use goroutine and sync.ErrorGroup
-		_, _ = bdr.build()
+	builders := gx.builders()
+	results := make([]string, len(builders))
+
+	buildErr := runBounded(len(builders), gx.parallelism, func(i int) error {
+		bdr := builders[i]
+		archive, err := bdr.build()
+		results[i] = formatBuildResult(bdr.platform, archive, err)
+		return err
+	})
+
+	fmt.Fprintln(cl.outStream, "build summary:")
+	for _, r := range results {
+		fmt.Fprintf(cl.outStream, "  %s\n", r)
+	}
+
+	if err := writeChecksums(gx.getDest(), gx.checksum); err != nil {
+		return err
+	}
+
+	if buildErr != nil {
+		return fmt.Errorf("one or more builds failed")
 	}
 
 	return nil
 }
 
+// runBounded runs task(i) for every i in [0, n), at most parallelism at a
+// time, and waits for all of them to finish. It returns the first error
+// returned by any task (if any), but unlike an early-exit loop it always
+// lets every task run to completion first.
+func runBounded(n, parallelism int, task func(i int) error) error {
+	var eg errgroup.Group
+	eg.SetLimit(parallelism)
+	for i := 0; i < n; i++ {
+		i := i
+		eg.Go(func() error {
+			return task(i)
+		})
+	}
+	return eg.Wait()
+}
+
+// formatBuildResult renders a single line of runOne's build summary output.
+func formatBuildResult(pf fmt.Stringer, archive string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("NG  %s: %s", pf, err)
+	}
+	return fmt.Sprintf("OK  %s: %s", pf, archive)
+}
+
 func (cl *cli) parseArgs(args []string) (*goxz, error) {
 	gx := &goxz{}
 	fs := flag.NewFlagSet("goxz", flag.ContinueOnError)
@@ -108,6 +235,15 @@ func (cl *cli) parseArgs(args []string) (*goxz, error) {
 	fs.StringVar(&gx.buildLdFlags, "build-ldflags", "", "arguments to pass on each go tool link invocation")
 	fs.StringVar(&gx.buildTags, "build-tags", "", "a space-separated list of build `tags`")
 	fs.BoolVar(&gx.zipAlways, "zip", false, "zip always")
+	fs.IntVar(&gx.parallelism, "p", runtime.NumCPU(), "the number of platforms to build in parallel")
+	fs.StringVar(&gx.checksum, "checksum", "", "comma-separated checksum algorithms to generate manifests for (sha1, sha256, sha512)")
+	fs.StringVar(&gx.format, "format", "", "archive format: zip, tar.gz, tar.xz, tar.zst or tar.bz2 (default: zip for windows, tar.gz otherwise)")
+	fs.StringVar(&gx.cc, "cc", "", "comma-separated os/arch=compiler mapping for the C compiler used on cgo-enabled cross builds, e.g. linux/arm64=aarch64-linux-gnu-gcc")
+	fs.StringVar(&gx.cxx, "cxx", "", "comma-separated os/arch=compiler mapping for the C++ compiler used on cgo-enabled cross builds")
+	fs.Var(&gx.cgo, "cgo", "force CGO_ENABLED on (true) or off (false) for all platforms, overriding any -cc/-cxx mapping; unset derives it from whether a mapping exists")
+	fs.StringVar(&gx.buildmode, "buildmode", "", "build mode to pass to go build: default, pie, c-archive, c-shared or plugin")
+	fs.StringVar(&gx.config, "config", "", "path to a goxz.yaml/goxz.toml file describing a release matrix; when set, all other build flags are ignored")
+	fs.StringVar(&gx.archVariants, "arch-variants", "", "comma-separated os/arch@variant entries pinning a microarchitecture level, e.g. linux/arm@7,linux/amd64@v3 (also accepted as an arch@variant suffix on -arch)")
 
 	fs.StringVar(&gx.projDir, "C", "", "[for debug] change directory")
 	fs.BoolVar(&gx.work, "work", false, "[for debug] print the name of the temporary work directory and do not delete it when exiting.")
@@ -136,11 +272,14 @@ func (gx *goxz) init() error {
 		gx.name = filepath.Base(gx.projDir)
 	}
 
-	err := setupDest(gx.getDest())
-	if err != nil {
-		return err
+	if !gx.skipDestSetup {
+		if err := setupDest(gx.getDest()); err != nil {
+			return err
+		}
 	}
 
+	var err error
+
 	// TODO: implement build constraints
 	// fill the defaults
 	if gx.os == "" {
@@ -149,22 +288,64 @@ func (gx *goxz) init() error {
 	if gx.arch == "" {
 		gx.arch = "amd64"
 	}
-	gx.platforms, err = resolvePlatforms(gx.os, gx.arch)
+	if gx.parallelism <= 0 {
+		gx.parallelism = runtime.NumCPU()
+	}
+	if !validFormats[gx.format] {
+		return fmt.Errorf("unsupported -format %q (want zip, tar.gz, tar.xz, tar.zst or tar.bz2)", gx.format)
+	}
+	gx.platforms, err = resolvePlatforms(gx.os, gx.arch, gx.archVariants)
 	if err != nil {
 		return err
 	}
+	beforeFilter := len(gx.platforms)
+	gx.platforms = filterPlatformsForBuildMode(gx.platforms, gx.buildmode)
+	if gx.buildmode != "" && gx.buildmode != "default" && beforeFilter > 0 && len(gx.platforms) == 0 {
+		return fmt.Errorf("no platform supports buildmode %q", gx.buildmode)
+	}
 	gx.resources, err = gatherResources(gx.projDir)
 	if err != nil {
 		return err
 	}
+	gx.resources = append(gx.resources, gx.extraResources...)
+
+	gx.ccMap, err = parseCompilerMap(gx.cc)
+	if err != nil {
+		return err
+	}
+	gx.cxxMap, err = parseCompilerMap(gx.cxx)
+	if err != nil {
+		return err
+	}
 
 	gx.absPkgs, err = goAbsPkgs(gx.pkgs, gx.projDir)
 	return err
 }
 
+// parseCompilerMap parses a comma-separated "os/arch=compiler" list, as
+// accepted by -cc and -cxx, into a lookup keyed by "os/arch".
+func parseCompilerMap(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid compiler mapping %q, want os/arch=compiler", entry)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
 var separateReg = regexp.MustCompile(`\s*(?:\s+|,)\s*`)
 
-func resolvePlatforms(os, arch string) ([]*platform, error) {
+func resolvePlatforms(os, arch, archVariants string) ([]*platform, error) {
 	platforms := []*platform{}
 	osTargets := separateReg.Split(os, -1)
 	archTargets := separateReg.Split(arch, -1)
@@ -172,17 +353,30 @@ func resolvePlatforms(os, arch string) ([]*platform, error) {
 		if strings.TrimSpace(os) == "" {
 			continue
 		}
-		for _, arch := range archTargets {
-			if strings.TrimSpace(arch) == "" {
+		for _, archTarget := range archTargets {
+			if strings.TrimSpace(archTarget) == "" {
 				continue
 			}
-			platforms = append(platforms, &platform{os: os, arch: arch})
+			arch, variant := splitArchVariant(archTarget)
+			platforms = append(platforms, &platform{os: os, arch: arch, variant: variant})
 		}
 	}
+
+	extra, err := parseArchVariants(archVariants)
+	if err != nil {
+		return nil, err
+	}
+	platforms = append(platforms, extra...)
+
 	uniqPlatforms := []*platform{}
 	seen := make(map[string]struct{})
 	for _, pf := range platforms {
-		key := pf.os + ":" + pf.arch
+		if pf.variant != "" {
+			if key, _ := pf.variantEnv(); key == "" {
+				return nil, fmt.Errorf("%s does not support a microarchitecture variant (%s/%s@%s)", pf.arch, pf.os, pf.arch, pf.variant)
+			}
+		}
+		key := pf.os + ":" + pf.arch + ":" + pf.variant
 		_, ok := seen[key]
 		if !ok {
 			seen[key] = struct{}{}
@@ -192,6 +386,36 @@ func resolvePlatforms(os, arch string) ([]*platform, error) {
 	return uniqPlatforms, nil
 }
 
+// splitArchVariant splits an "arch@variant" token (e.g. "arm@7") into its
+// arch and variant parts. Tokens without "@" return an empty variant.
+func splitArchVariant(archTarget string) (arch, variant string) {
+	parts := strings.SplitN(archTarget, "@", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// parseArchVariants parses the comma/space-separated -arch-variants list of
+// "os/arch@variant" tokens into explicit platform entries, bypassing the
+// usual os x arch cross product.
+func parseArchVariants(s string) ([]*platform, error) {
+	var platforms []*platform
+	for _, token := range separateReg.Split(s, -1) {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		osArch := strings.SplitN(token, "/", 2)
+		if len(osArch) != 2 {
+			return nil, fmt.Errorf("invalid -arch-variants entry %q, want os/arch@variant", token)
+		}
+		arch, variant := splitArchVariant(osArch[1])
+		platforms = append(platforms, &platform{os: osArch[0], arch: arch, variant: variant})
+	}
+	return platforms, nil
+}
+
 func (gx *goxz) getDest() string {
 	if gx.dest == "" {
 		gx.dest = "goxz"
@@ -213,7 +437,7 @@ func setupDest(dir string) error {
 			continue
 		}
 		n := f.Name()
-		if strings.HasPrefix(n, ".zip") || strings.HasPrefix(n, ".tar.gz") {
+		if archiveExtReg.MatchString(n) {
 			fpath := filepath.Join(dir, n)
 			log.Printf("removing %q", fpath)
 			err := os.Remove(fpath)
@@ -273,6 +497,17 @@ func gatherResources(dir string) ([]string, error) {
 func (gx *goxz) builders() []*builder {
 	builders := make([]*builder, len(gx.platforms))
 	for i, pf := range gx.platforms {
+		cc, hasCC := gx.ccMap[pf.os+"/"+pf.arch]
+		cxx, hasCXX := gx.cxxMap[pf.os+"/"+pf.arch]
+
+		env := make(map[string]string, len(gx.extraEnv)+1)
+		for k, v := range gx.extraEnv {
+			env[k] = v
+		}
+		if key, value := pf.variantEnv(); key != "" {
+			env[key] = value
+		}
+
 		builders[i] = &builder{
 			platform:     pf,
 			name:         gx.name,
@@ -282,8 +517,15 @@ func (gx *goxz) builders() []*builder {
 			buildTags:    gx.buildTags,
 			pkgs:         gx.absPkgs,
 			zipAlways:    gx.zipAlways,
+			format:       gx.format,
 			workDirBase:  gx.workDir,
 			resources:    gx.resources,
+			dest:         gx.getDest(),
+			cc:           cc,
+			cxx:          cxx,
+			cgoEnabled:   resolveCgoEnabled(gx.cgo, hasCC, hasCXX),
+			buildmode:    gx.buildmode,
+			extraEnv:     env,
 		}
 	}
 	return builders
@@ -0,0 +1,65 @@
+package goxz
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunBoundedRespectsParallelismLimit(t *testing.T) {
+	const n = 20
+	const limit = 3
+
+	var cur, max int32
+	err := runBounded(n, limit, func(i int) error {
+		c := atomic.AddInt32(&cur, 1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if c <= m || atomic.CompareAndSwapInt32(&max, m, c) {
+				break
+			}
+		}
+		atomic.AddInt32(&cur, -1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runBounded() unexpected error: %s", err)
+	}
+	if max > limit {
+		t.Errorf("runBounded() allowed %d concurrent tasks, want at most %d", max, limit)
+	}
+}
+
+func TestRunBoundedRunsEveryTaskAndReportsFailure(t *testing.T) {
+	const n = 5
+	var ran [n]bool
+	err := runBounded(n, 2, func(i int) error {
+		ran[i] = true
+		if i == 2 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("runBounded() error = nil, want error from the failing task")
+	}
+	for i, r := range ran {
+		if !r {
+			t.Errorf("runBounded() task %d did not run, want all tasks to run to completion", i)
+		}
+	}
+}
+
+func TestFormatBuildResult(t *testing.T) {
+	pf := &platform{os: "linux", arch: "amd64"}
+
+	if got, want := formatBuildResult(pf, "goxz/myapp_linux_amd64.tar.gz", nil), fmt.Sprintf("OK  %s: goxz/myapp_linux_amd64.tar.gz", pf); got != want {
+		t.Errorf("formatBuildResult() = %q, want %q", got, want)
+	}
+
+	err := errors.New("exit status 1")
+	if got, want := formatBuildResult(pf, "", err), fmt.Sprintf("NG  %s: %s", pf, err); got != want {
+		t.Errorf("formatBuildResult() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package goxz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCompilerMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: map[string]string{}},
+		{
+			name: "single entry",
+			in:   "linux/arm64=aarch64-linux-gnu-gcc",
+			want: map[string]string{"linux/arm64": "aarch64-linux-gnu-gcc"},
+		},
+		{
+			name: "multiple entries",
+			in:   "linux/arm64=aarch64-linux-gnu-gcc,windows/amd64=x86_64-w64-mingw32-gcc",
+			want: map[string]string{
+				"linux/arm64":   "aarch64-linux-gnu-gcc",
+				"windows/amd64": "x86_64-w64-mingw32-gcc",
+			},
+		},
+		{
+			name: "compiler value with spaces",
+			in:   "linux/amd64=gcc -m32",
+			want: map[string]string{"linux/amd64": "gcc -m32"},
+		},
+		{
+			name:    "missing equals",
+			in:      "linux/amd64",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCompilerMap(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCompilerMap(%q) error = nil, want error", tt.in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCompilerMap(%q) unexpected error: %s", tt.in, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCompilerMap(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCgoEnabled(t *testing.T) {
+	tests := []struct {
+		name          string
+		override      cgoOverride
+		hasCC, hasCXX bool
+		want          bool
+	}{
+		{name: "unset, no mapping", override: cgoOverride{}, want: false},
+		{name: "unset, cc mapping", override: cgoOverride{}, hasCC: true, want: true},
+		{name: "unset, cxx mapping", override: cgoOverride{}, hasCXX: true, want: true},
+		{name: "forced true, no mapping", override: cgoOverride{set: true, value: true}, want: true},
+		{name: "forced false, with mapping", override: cgoOverride{set: true, value: false}, hasCC: true, hasCXX: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveCgoEnabled(tt.override, tt.hasCC, tt.hasCXX)
+			if got != tt.want {
+				t.Errorf("resolveCgoEnabled(%+v, %v, %v) = %v, want %v", tt.override, tt.hasCC, tt.hasCXX, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package goxz
+
+import "fmt"
+
+// platform represents a single GOOS/GOARCH target to build for, optionally
+// pinned to a microarchitecture level (variant), e.g. "v3" for amd64,
+// "6"/"7" for arm, or "softfloat" for mips.
+type platform struct {
+	os, arch string
+	variant  string
+}
+
+func (pf *platform) String() string {
+	if pf.variant == "" {
+		return fmt.Sprintf("%s/%s", pf.os, pf.arch)
+	}
+	return fmt.Sprintf("%s/%s@%s", pf.os, pf.arch, pf.variant)
+}
+
+// variantEnv returns the GOARM/GOAMD64/GOMIPS/GO386 environment variable
+// that applies pf's variant, or ("", "") if pf has none.
+func (pf *platform) variantEnv() (key, value string) {
+	if pf.variant == "" {
+		return "", ""
+	}
+	switch pf.arch {
+	case "arm":
+		return "GOARM", pf.variant
+	case "amd64":
+		return "GOAMD64", pf.variant
+	case "386":
+		return "GO386", pf.variant
+	case "mips", "mipsle":
+		return "GOMIPS", pf.variant
+	case "mips64", "mips64le":
+		return "GOMIPS64", pf.variant
+	default:
+		return "", ""
+	}
+}
+
+// archSuffix returns the token used in archive filenames to represent pf's
+// arch and variant, e.g. "armv7" or "amd64_v3". Preserves the bare arch
+// name when pf has no variant.
+func (pf *platform) archSuffix() string {
+	if pf.variant == "" {
+		return pf.arch
+	}
+	if pf.arch == "arm" {
+		return pf.arch + "v" + pf.variant
+	}
+	return pf.arch + "_" + pf.variant
+}
@@ -0,0 +1,92 @@
+package goxz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArchVariant(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantArch    string
+		wantVariant string
+	}{
+		{in: "amd64", wantArch: "amd64", wantVariant: ""},
+		{in: "amd64@v3", wantArch: "amd64", wantVariant: "v3"},
+		{in: "arm@7", wantArch: "arm", wantVariant: "7"},
+		{in: "mips@softfloat", wantArch: "mips", wantVariant: "softfloat"},
+	}
+	for _, tt := range tests {
+		arch, variant := splitArchVariant(tt.in)
+		if arch != tt.wantArch || variant != tt.wantVariant {
+			t.Errorf("splitArchVariant(%q) = (%q, %q), want (%q, %q)", tt.in, arch, variant, tt.wantArch, tt.wantVariant)
+		}
+	}
+}
+
+func TestParseArchVariants(t *testing.T) {
+	got, err := parseArchVariants("linux/arm@6,linux/arm@7,linux/amd64@v3")
+	if err != nil {
+		t.Fatalf("parseArchVariants() unexpected error: %s", err)
+	}
+	want := []*platform{
+		{os: "linux", arch: "arm", variant: "6"},
+		{os: "linux", arch: "arm", variant: "7"},
+		{os: "linux", arch: "amd64", variant: "v3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseArchVariants() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseArchVariantsInvalid(t *testing.T) {
+	if _, err := parseArchVariants("linux-arm"); err == nil {
+		t.Fatal("parseArchVariants() error = nil, want error for entry missing os/arch separator")
+	}
+}
+
+func TestResolvePlatformsDistinctVariantsDontCollapse(t *testing.T) {
+	platforms, err := resolvePlatforms("linux", "amd64@v1 amd64@v2", "")
+	if err != nil {
+		t.Fatalf("resolvePlatforms() unexpected error: %s", err)
+	}
+	if len(platforms) != 2 {
+		t.Fatalf("resolvePlatforms() returned %d platforms, want 2", len(platforms))
+	}
+}
+
+func TestResolvePlatformsRejectsUnsupportedVariantArch(t *testing.T) {
+	if _, err := resolvePlatforms("linux", "arm64@v8", ""); err == nil {
+		t.Fatal("resolvePlatforms() error = nil, want error for arch without a variant env var")
+	}
+}
+
+func TestVariantEnv(t *testing.T) {
+	tests := []struct {
+		arch, variant string
+		wantKey       string
+	}{
+		{arch: "arm", variant: "7", wantKey: "GOARM"},
+		{arch: "amd64", variant: "v3", wantKey: "GOAMD64"},
+		{arch: "386", variant: "softfloat", wantKey: "GO386"},
+		{arch: "mips", variant: "softfloat", wantKey: "GOMIPS"},
+		{arch: "mipsle", variant: "softfloat", wantKey: "GOMIPS"},
+		{arch: "mips64", variant: "softfloat", wantKey: "GOMIPS64"},
+		{arch: "mips64le", variant: "softfloat", wantKey: "GOMIPS64"},
+	}
+	for _, tt := range tests {
+		pf := &platform{os: "linux", arch: tt.arch, variant: tt.variant}
+		key, value := pf.variantEnv()
+		if key != tt.wantKey || value != tt.variant {
+			t.Errorf("(%s@%s).variantEnv() = (%q, %q), want (%q, %q)", tt.arch, tt.variant, key, value, tt.wantKey, tt.variant)
+		}
+	}
+}
+
+func TestVariantEnvNoVariant(t *testing.T) {
+	pf := &platform{os: "linux", arch: "amd64"}
+	key, value := pf.variantEnv()
+	if key != "" || value != "" {
+		t.Errorf("variantEnv() = (%q, %q), want (\"\", \"\")", key, value)
+	}
+}